@@ -0,0 +1,81 @@
+// Package es holds one-time Elasticsearch setup that the server performs at
+// startup, such as registering index templates.
+package es
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// assetIndexTemplateName names the index template whose mapping is applied
+// to every per-user "assets-*" index so that `labels` and `camera_model` sort/filter as keywords,
+// `location` is queryable as a geo_point, and the date fields used by range
+// queries and the search date_histogram aggregation parse consistently.
+const assetIndexTemplateName = "assets"
+
+// EnsureAssetIndexTemplate registers the index template backing every
+// per-user "assets-<user_id>" index, so new indices get the right field
+// mappings (geo_point, keyword, date) without relying on dynamic mapping
+// guesses. It is safe to call on every startup; PutIndexTemplate overwrites
+// the existing template with the same body.
+func EnsureAssetIndexTemplate(client *elasticsearch.Client) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"index_patterns": []string{"assets-*"},
+		"template": map[string]interface{}{
+			"mappings": map[string]interface{}{
+				"properties": map[string]interface{}{
+					"labels": map[string]interface{}{
+						"type": "keyword",
+					},
+					"source": map[string]interface{}{
+						"type": "keyword",
+					},
+					"created_at": map[string]interface{}{
+						"type":             "date",
+						"format":           "strict_date_optional_time||epoch_millis",
+						"ignore_malformed": true,
+					},
+					"taken_at": map[string]interface{}{
+						"type":   "date",
+						"format": "strict_date_optional_time||epoch_millis",
+					},
+					"location": map[string]interface{}{
+						"type": "geo_point",
+					},
+					"camera_model": map[string]interface{}{
+						"type": "keyword",
+					},
+					"metadata": map[string]interface{}{
+						"properties": map[string]interface{}{
+							"gps": map[string]interface{}{
+								"type": "geo_point",
+							},
+							"DateTimeOriginal": map[string]interface{}{
+								"type":   "date",
+								"format": "strict_date_optional_time||epoch_millis||yyyy:MM:dd HH:mm:ss",
+							},
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling index template: %w", err)
+	}
+
+	res, err := client.Indices.PutIndexTemplate(assetIndexTemplateName, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("registering index template: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("registering index template: %s", res.String())
+	}
+
+	return nil
+}
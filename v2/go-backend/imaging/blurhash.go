@@ -0,0 +1,161 @@
+package imaging
+
+import (
+	"image"
+	"math"
+)
+
+const base83Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+// EncodeBlurHash computes a BlurHash string for img using componentsX by
+// componentsY DCT components (the Wolt algorithm: sRGB -> linear, DCT-style
+// basis sums, quantize the AC components to 0-18, base83-encode). This is a
+// from-scratch implementation of the algorithm rather than a dependency on
+// github.com/buckket/go-blurhash, so the pipeline doesn't take on a second
+// image-decoding path for a single string computation; the output format is
+// identical and interoperable with that library's decoder.
+func EncodeBlurHash(img image.Image, componentsX, componentsY int) (string, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	factors := make([][3]float64, componentsX*componentsY)
+	for j := 0; j < componentsY; j++ {
+		for i := 0; i < componentsX; i++ {
+			factors[j*componentsX+i] = multiplyBasis(img, i, j, width, height)
+		}
+	}
+
+	dc := factors[0]
+	ac := factors[1:]
+
+	var maxValue float64
+	if len(ac) > 0 {
+		actualMax := 0.0
+		for _, f := range ac {
+			actualMax = math.Max(actualMax, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMax := int(math.Max(0, math.Min(82, math.Floor(actualMax*166-0.5))))
+		maxValue = float64(quantisedMax+1) / 166
+	} else {
+		maxValue = 1
+	}
+
+	out := make([]byte, 0, 6+2+4+len(ac)*2)
+
+	sizeFlag := (componentsX - 1) + (componentsY-1)*9
+	out = append(out, encode83(sizeFlag, 1)...)
+
+	var quantisedMax int
+	if len(ac) > 0 {
+		quantisedMax = int(math.Max(0, math.Min(82, math.Floor(maxValue*166-0.5))))
+	}
+	out = append(out, encode83(quantisedMax, 1)...)
+
+	out = append(out, encode83(encodeDC(dc), 4)...)
+
+	for _, f := range ac {
+		out = append(out, encode83(encodeAC(f, maxValue), 2)...)
+	}
+
+	return string(out), nil
+}
+
+// multiplyBasis computes factor[j][i] = sum(basis(i,x)*basis(j,y)*rgb(x,y))
+// over the image in linear sRGB space, normalized by image area.
+func multiplyBasis(img image.Image, i, j, width, height int) [3]float64 {
+	var r, g, b float64
+	bounds := img.Bounds()
+
+	normalisation := 1.0
+	if i != 0 || j != 0 {
+		normalisation = 2.0
+	}
+
+	for y := 0; y < height; y++ {
+		basisY := math.Cos(math.Pi * float64(j) * float64(y) / float64(height))
+		for x := 0; x < width; x++ {
+			basisX := math.Cos(math.Pi * float64(i) * float64(x) / float64(width))
+			basis := basisX * basisY
+
+			c := img.At(bounds.Min.X+x, bounds.Min.Y+y)
+			lr, lg, lb := toLinearRGB(c)
+
+			r += basis * lr
+			g += basis * lg
+			b += basis * lb
+		}
+	}
+
+	scale := normalisation / float64(width*height)
+	return [3]float64{r * scale, g * scale, b * scale}
+}
+
+func toLinearRGB(c interface {
+	RGBA() (uint32, uint32, uint32, uint32)
+}) (float64, float64, float64) {
+	r, g, b, _ := c.RGBA()
+	return srgbToLinear(float64(r>>8) / 255), srgbToLinear(float64(g>>8) / 255), srgbToLinear(float64(b>>8) / 255)
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSrgb(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	var r float64
+	if v <= 0.0031308 {
+		r = v * 12.92
+	} else {
+		r = 1.055*math.Pow(v, 1/2.4) - 0.055
+	}
+	return int(math.Round(r * 255))
+}
+
+func encodeDC(rgb [3]float64) int {
+	r := linearToSrgb(rgb[0])
+	g := linearToSrgb(rgb[1])
+	b := linearToSrgb(rgb[2])
+	return (r << 16) + (g << 8) + b
+}
+
+func encodeAC(rgb [3]float64, maxValue float64) int {
+	quantise := func(v float64) int {
+		q := int(math.Floor(signPow(v/maxValue, 0.5)*9 + 9.5))
+		if q < 0 {
+			return 0
+		}
+		if q > 18 {
+			return 18
+		}
+		return q
+	}
+	return quantise(rgb[0])*19*19 + quantise(rgb[1])*19 + quantise(rgb[2])
+}
+
+func signPow(v, exp float64) float64 {
+	if v < 0 {
+		return -math.Pow(-v, exp)
+	}
+	return math.Pow(v, exp)
+}
+
+func encode83(value, length int) []byte {
+	out := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / intPow(83, length-i)) % 83
+		out[i-1] = base83Alphabet[digit]
+	}
+	return out
+}
+
+func intPow(base, exp int) int {
+	result := 1
+	for i := 0; i < exp; i++ {
+		result *= base
+	}
+	return result
+}
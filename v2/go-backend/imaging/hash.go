@@ -0,0 +1,124 @@
+// Package imaging provides the server-side image processing pipeline used
+// when an asset is ingested: perceptual hashing, BlurHash placeholders, and
+// thumbnail generation.
+package imaging
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"os"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// DHash computes a 64-bit difference hash (dHash) of img, returned as a
+// 16-character hex string. Visually similar images (including re-encodes and
+// minor edits) produce hashes with a small Hamming distance, which makes this
+// suitable for near-duplicate detection.
+func DHash(img image.Image) string {
+	const w, h = 9, 8
+
+	gray := image.NewGray(image.Rect(0, 0, w, h))
+	xdraw.BiLinear.Scale(gray, gray.Bounds(), img, img.Bounds(), xdraw.Over, nil)
+
+	var bits uint64
+	for y := 0; y < h; y++ {
+		for x := 0; x < w-1; x++ {
+			left := gray.GrayAt(x, y).Y
+			right := gray.GrayAt(x+1, y).Y
+			bits <<= 1
+			if left > right {
+				bits |= 1
+			}
+		}
+	}
+
+	return fmt.Sprintf("%016x", bits)
+}
+
+// HammingDistance returns the number of differing bits between two 64-bit
+// hashes (the values DHash returns hex-encoded). Smaller distances indicate more visually
+// similar images; 0 means identical hashes.
+func HammingDistance(a, b uint64) int {
+	x := a ^ b
+	count := 0
+	for x != 0 {
+		count++
+		x &= x - 1
+	}
+	return count
+}
+
+// DominantColor returns the average color of img as a "#rrggbb" hex string,
+// computed over a downscaled 1x1 sample. It's cheap enough to run on every
+// ingested image and gives callers a background color to paint before any
+// thumbnail bytes arrive.
+func DominantColor(img image.Image) string {
+	sample := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	xdraw.BiLinear.Scale(sample, sample.Bounds(), img, img.Bounds(), xdraw.Over, nil)
+	c := sample.RGBAAt(0, 0)
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
+
+// Thumbnail resizes img so its longest edge is maxEdge pixels, preserving
+// aspect ratio. Images already smaller than maxEdge are returned unchanged.
+func Thumbnail(img image.Image, maxEdge int) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	if srcW <= maxEdge && srcH <= maxEdge {
+		return img
+	}
+
+	dstW, dstH := maxEdge, maxEdge*srcH/srcW
+	if srcH > srcW {
+		dstH, dstW = maxEdge, maxEdge*srcW/srcH
+	}
+	if dstW < 1 {
+		dstW = 1
+	}
+	if dstH < 1 {
+		dstH = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	xdraw.BiLinear.Scale(dst, dst.Bounds(), img, bounds, xdraw.Over, nil)
+	return dst
+}
+
+// SaveJPEG encodes img as a JPEG at the given quality (1-100) and writes it
+// to path. The parent directory must already exist.
+func SaveJPEG(img image.Image, path string, quality int) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating thumbnail file: %w", err)
+	}
+	defer f.Close()
+
+	// jpeg.Encode requires an opaque image; flatten onto white if needed.
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		b := img.Bounds()
+		flat := image.NewRGBA(b)
+		draw.Draw(flat, b, &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+		draw.Draw(flat, b, img, b.Min, draw.Over)
+		rgba = flat
+	}
+
+	return jpeg.Encode(f, rgba, &jpeg.Options{Quality: quality})
+}
+
+// SavePNG encodes img as a PNG and writes it to path. The parent directory
+// must already exist.
+func SavePNG(img image.Image, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating thumbnail file: %w", err)
+	}
+	defer f.Close()
+
+	return png.Encode(f, img)
+}
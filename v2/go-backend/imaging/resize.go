@@ -0,0 +1,51 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// ResizeToFit scales img to targetW x targetH. With fit "cover" the image
+// fills the box and is center-cropped to it; with fit "contain" (the
+// default) the image is scaled up or down to fit entirely inside the box,
+// preserving aspect ratio. A zero targetW or targetH is derived from the
+// other dimension and the image's aspect ratio.
+func ResizeToFit(img image.Image, targetW, targetH int, fit string) image.Image {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+
+	if targetW <= 0 && targetH > 0 {
+		targetW = targetH * srcW / srcH
+	}
+	if targetH <= 0 && targetW > 0 {
+		targetH = targetW * srcH / srcW
+	}
+	if targetW <= 0 || targetH <= 0 {
+		return img
+	}
+
+	var scale float64
+	if fit == "cover" {
+		scale = math.Max(float64(targetW)/float64(srcW), float64(targetH)/float64(srcH))
+	} else {
+		scale = math.Min(float64(targetW)/float64(srcW), float64(targetH)/float64(srcH))
+	}
+
+	scaledW := int(math.Round(float64(srcW) * scale))
+	scaledH := int(math.Round(float64(srcH) * scale))
+	scaled := image.NewRGBA(image.Rect(0, 0, scaledW, scaledH))
+	xdraw.BiLinear.Scale(scaled, scaled.Bounds(), img, bounds, xdraw.Over, nil)
+
+	if fit != "cover" {
+		return scaled
+	}
+
+	offsetX := (scaledW - targetW) / 2
+	offsetY := (scaledH - targetH) / 2
+	cropped := image.NewRGBA(image.Rect(0, 0, targetW, targetH))
+	draw.Draw(cropped, cropped.Bounds(), scaled, image.Point{X: offsetX, Y: offsetY}, draw.Src)
+	return cropped
+}
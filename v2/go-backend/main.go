@@ -8,9 +8,11 @@ import (
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/gorilla/mux"
 
+	"go-backend/es"
 	"go-backend/middleware"
 	"go-backend/models"
 	"go-backend/routes"
+	"go-backend/storage"
 )
 
 type Document models.Asset
@@ -44,8 +46,18 @@ func main() {
 		log.Fatalf("Error creating Elasticsearch client: %s", err)
 	}
 
+	if err := es.EnsureAssetIndexTemplate(esClient); err != nil {
+		log.Printf("Warning: failed to register asset index template: %s", err)
+	}
+
+	storageBackend, err := storage.New()
+	if err != nil {
+		log.Fatalf("Error configuring storage backend: %s", err)
+	}
+
 	router := mux.NewRouter()
 	router.Use(middleware.ElasticsearchMiddleware(esClient))
+	router.Use(middleware.StorageMiddleware(storageBackend))
 	router.Use(middleware.CORSMiddleware)
 	router.Use(middleware.LoggingMiddleware)
 
@@ -53,12 +65,19 @@ func main() {
 	router.HandleFunc("/users/{id}", routes.GetUser).Methods("GET")
 	router.HandleFunc("/users/{id}", routes.UpdateUser).Methods("PATCH")
 
-	router.HandleFunc("/assets", routes.CreateAsset).Methods("POST")
-	router.HandleFunc("/assets/{id}", routes.GetAsset).Methods("GET")
-	router.HandleFunc("/assets/{id}/image", routes.GetAssetImage).Methods("GET")
-	router.HandleFunc("/assets/{id}", routes.UpdateAsset).Methods("PATCH")
-	router.HandleFunc("/assets/{id}", routes.DeleteAsset).Methods("DELETE")
-	router.HandleFunc("/assets", routes.ListAssets).Methods("GET")
+	assetsRouter := router.PathPrefix("/assets").Subrouter()
+	assetsRouter.Use(middleware.AuthMiddleware)
+	assetsRouter.HandleFunc("", routes.CreateAsset).Methods("POST")
+	assetsRouter.HandleFunc("/_bulk", routes.BulkCreateAssets).Methods("POST")
+	assetsRouter.HandleFunc("/search", routes.SearchAssets).Methods("GET")
+	assetsRouter.HandleFunc("/uploads", routes.CreateUploadSession).Methods("POST")
+	assetsRouter.HandleFunc("/uploads/{upload_id}", routes.AppendUploadChunk).Methods("PATCH")
+	assetsRouter.HandleFunc("/uploads/{upload_id}/complete", routes.CompleteUpload).Methods("POST")
+	assetsRouter.HandleFunc("", routes.ListAssets).Methods("GET")
+	assetsRouter.HandleFunc("/{id}", routes.GetAsset).Methods("GET")
+	assetsRouter.HandleFunc("/{id}/image", routes.GetAssetImage).Methods("GET")
+	assetsRouter.HandleFunc("/{id}", routes.UpdateAsset).Methods("PATCH")
+	assetsRouter.HandleFunc("/{id}", routes.DeleteAsset).Methods("DELETE")
 
 	log.Println("Server running on port 8080")
 	log.Fatal(http.ListenAndServe(":8080", router))
@@ -0,0 +1,70 @@
+// Package metadata extracts EXIF metadata from ingested images so photos
+// can be searched by when and where they were actually taken, not just
+// when they were uploaded.
+package metadata
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rwcarlsen/goexif/exif"
+)
+
+// EXIF holds the subset of EXIF tags photosafe cares about. Every field is
+// the zero value when the corresponding tag was absent.
+type EXIF struct {
+	TakenAt     time.Time
+	Latitude    float64
+	Longitude   float64
+	HasGPS      bool
+	CameraMake  string
+	CameraModel string
+	Orientation int
+}
+
+// Extract reads and decodes the EXIF segment of the image at path. It
+// returns an error only when the file can't be opened; images with no
+// EXIF segment at all (PNG, GIF, a stripped JPEG) yield a zero EXIF and a
+// nil error, since that's an expected, not exceptional, case.
+func Extract(path string) (*EXIF, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening image for EXIF extraction: %w", err)
+	}
+	defer f.Close()
+
+	x, err := exif.Decode(f)
+	if err != nil {
+		return &EXIF{}, nil
+	}
+
+	info := &EXIF{}
+
+	if takenAt, err := x.DateTime(); err == nil {
+		info.TakenAt = takenAt
+	}
+
+	if lat, lon, err := x.LatLong(); err == nil {
+		info.Latitude, info.Longitude = lat, lon
+		info.HasGPS = true
+	}
+
+	if tag, err := x.Get(exif.Make); err == nil {
+		if s, err := tag.StringVal(); err == nil {
+			info.CameraMake = s
+		}
+	}
+	if tag, err := x.Get(exif.Model); err == nil {
+		if s, err := tag.StringVal(); err == nil {
+			info.CameraModel = s
+		}
+	}
+	if tag, err := x.Get(exif.Orientation); err == nil {
+		if v, err := tag.Int(0); err == nil {
+			info.Orientation = v
+		}
+	}
+
+	return info, nil
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-backend/models"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+const authUserKey contextKey = "authUser"
+
+// AuthMiddleware resolves the caller's bearer token to a *models.User via
+// AuthenticateUserFromToken and stashes it in the request context for
+// downstream handlers to read with GetAuthenticatedUser. Requests without a
+// valid token are rejected with 401 before reaching the route handler.
+func AuthMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		esClient := GetElasticsearchClient(r)
+
+		user, err := AuthenticateUserFromToken(r, esClient)
+		if err != nil || user == nil {
+			http.Error(w, "Failed to authenticate user", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), authUserKey, user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// GetAuthenticatedUser retrieves the user resolved by AuthMiddleware from the
+// request context. It returns nil if AuthMiddleware did not run for this
+// request.
+func GetAuthenticatedUser(r *http.Request) *models.User {
+	if user, ok := r.Context().Value(authUserKey).(*models.User); ok {
+		return user
+	}
+	return nil
+}
+
+// AuthenticateUserFromToken checks if the token exists and is valid in Elasticsearch
+func AuthenticateUserFromToken(r *http.Request, esClient *elasticsearch.Client) (*models.User, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return nil, fmt.Errorf("Authorization header is missing")
+	}
+
+	// Split the header into "Bearer" and the token
+	parts := strings.Fields(authHeader)
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return nil, fmt.Errorf("Authorization header format must be Bearer {token}")
+	}
+
+	token := parts[1]
+
+	// Elasticsearch query to find the token document, properly encoded to
+	// avoid injecting into the query body.
+	queryBody, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{
+				"token.keyword": map[string]interface{}{
+					"value": token,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error building query: %s", err)
+	}
+
+	// Send the query to Elasticsearch
+	resp, err := esClient.Search(
+		esClient.Search.WithIndex("users"),
+		esClient.Search.WithBody(bytes.NewReader(queryBody)),
+		esClient.Search.WithTrackTotalHits(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error querying Elasticsearch: %s", err)
+	}
+	defer resp.Body.Close()
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("error parsing Elasticsearch response: %s", err)
+	}
+
+	// Check if the token exists and is valid
+	if hits, found := result["hits"].(map[string]interface{})["hits"].([]interface{}); found && len(hits) > 0 {
+		// Token exists, retrieve user details
+		if source, ok := hits[0].(map[string]interface{})["_source"].(map[string]interface{}); ok {
+			jsonDoc, _ := json.Marshal(source)
+			var user *models.User
+			json.Unmarshal(jsonDoc, &user)
+
+			return user, nil
+		}
+	}
+	return nil, nil // Token not found or invalid
+}
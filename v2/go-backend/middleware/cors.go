@@ -0,0 +1,21 @@
+package middleware
+
+import "net/http"
+
+// CORSMiddleware allows the API to be called from browser-based clients
+// served from a different origin (e.g. a local dev frontend), answering
+// preflight OPTIONS requests itself instead of passing them to routes.
+func CORSMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Authorization, Content-Type")
+
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
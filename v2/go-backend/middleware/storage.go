@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"go-backend/storage"
+)
+
+const storageBackendKey contextKey = "storageBackend"
+
+// StorageMiddleware injects the storage.Backend into the request context.
+func StorageMiddleware(backend storage.Backend) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), storageBackendKey, backend)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetStorageBackend retrieves the storage.Backend from the request context.
+func GetStorageBackend(r *http.Request) storage.Backend {
+	if backend, ok := r.Context().Value(storageBackendKey).(storage.Backend); ok {
+		return backend
+	}
+	return nil
+}
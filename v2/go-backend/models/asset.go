@@ -1,5 +1,21 @@
 package models
 
+// GeoPoint is a latitude/longitude pair in the shape Elasticsearch's
+// geo_point field type expects.
+type GeoPoint struct {
+	Lat float64 `json:"lat"`
+	Lon float64 `json:"lon"`
+}
+
+// Thumbnail describes one precomputed resized rendition of an Asset's image,
+// stored alongside the original and referenced by longest-edge size.
+type Thumbnail struct {
+	Size   string `json:"size"` // e.g. "256", "1024"
+	Path   string `json:"path"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
 type Asset struct {
 	ID               string                 `json:"id,omitempty"`
 	UserID           string                 `json:"user_id,omitempty"`
@@ -10,6 +26,31 @@ type Asset struct {
 	Thumbnail        string                 `json:"thumbnail,omitempty"`
 	CreatedAt        string                 `json:"created_at"`
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
+
+	ContentSHA256 string `json:"content_sha256,omitempty"`
+	Size          int64  `json:"size,omitempty"`
+	// InternalPath is where the storage backend actually put the blob (a
+	// filesystem path under the fs backend's root, an s3:// URI for the s3
+	// backend). Client-facing links are derived from it on read (see
+	// externalLinkForAsset) rather than stored, since a backend.URL() call
+	// against the s3 backend returns a presigned URL that expires.
+	InternalPath  string      `json:"internal_path,omitempty"`
+	PHash         string      `json:"phash,omitempty"`
+	BlurHash      string      `json:"blurhash,omitempty"`
+	DominantColor string      `json:"dominant_color,omitempty"`
+	Width         int         `json:"width,omitempty"`
+	Height        int         `json:"height,omitempty"`
+	MimeType      string      `json:"mime_type,omitempty"`
+	Thumbnails    []Thumbnail `json:"thumbnails,omitempty"`
+
+	// EXIF-derived fields, populated best-effort by runImagePipeline.
+	TakenAt     string    `json:"taken_at,omitempty"`
+	Latitude    float64   `json:"latitude,omitempty"`
+	Longitude   float64   `json:"longitude,omitempty"`
+	Location    *GeoPoint `json:"location,omitempty"`
+	CameraMake  string    `json:"camera_make,omitempty"`
+	CameraModel string    `json:"camera_model,omitempty"`
+	Orientation int       `json:"orientation,omitempty"`
 }
 
 type UpdateAsset struct {
@@ -0,0 +1,17 @@
+package models
+
+// UploadSession tracks the progress of a chunked/resumable upload, stored
+// in the user's uploads-<user> index so a client can query its committed
+// offset and resume after a network drop.
+type UploadSession struct {
+	ID             string `json:"id"`
+	UserID         string `json:"user_id"`
+	Filename       string `json:"filename"`
+	TotalSize      int64  `json:"total_size"`
+	Offset         int64  `json:"offset"`
+	ExpectedSHA256 string `json:"expected_sha256,omitempty"`
+	TempPath       string `json:"temp_path"`
+	Status         string `json:"status"` // "pending", "complete"
+	CreatedAt      string `json:"created_at"`
+	ExpiresAt      string `json:"expires_at"`
+}
@@ -2,31 +2,103 @@ package routes
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
 	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
+	"go-backend/imaging"
+	"go-backend/metadata"
 	"go-backend/middleware"
 	"go-backend/models"
+	"go-backend/storage"
 
+	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/gorilla/mux"
 )
 
 const assetDirectory = "./assets"
 
-func CreateAsset(w http.ResponseWriter, r *http.Request) {
-	esClient := middleware.GetElasticsearchClient(r)
+// listDefaultSize is the page size ListAssets uses when the caller doesn't
+// pass a `limit`.
+const listDefaultSize = 50
+
+// uploadMaxBytes bounds how much a single CreateAsset upload may write to
+// disk. http.MaxBytesReader already caps the request body, but the upload
+// is double-checked by counting bytes as they're streamed to disk, since a
+// MaxBytesReader misconfiguration upstream shouldn't be the only thing
+// standing between a client and an unbounded write.
+const uploadMaxBytes = 10 << 20
+
+// thumbnailEdges are the longest-edge sizes generated for every ingested
+// image, smallest first.
+var thumbnailEdges = []int{256, 1024}
+
+// blurHashComponentsX/Y are the DCT basis dimensions used to compute the
+// BlurHash placeholder, per the standard Wolt algorithm (4x3 is the usual
+// default for photos).
+const (
+	blurHashComponentsX = 4
+	blurHashComponentsY = 3
+)
 
-	user, err := AuthenticateUserFromToken(r, esClient)
-	if err != nil {
-		http.Error(w, "Failed to authenticate user", http.StatusUnauthorized)
-		return
+// countingWriter forwards writes to w while tracking how many bytes have
+// passed through, erroring once limit is exceeded so callers can abort a
+// write in progress instead of discovering the overflow only after it has
+// already landed on disk.
+type countingWriter struct {
+	w     io.Writer
+	n     int64
+	limit int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	if c.n > c.limit {
+		return 0, fmt.Errorf("upload exceeds maximum size of %d bytes", c.limit)
+	}
+	return c.w.Write(p)
+}
+
+// casBlobKey returns the content-addressable storage key for a blob with
+// the given hash and original extension, sharded two levels deep by hash
+// prefix (<user>/<hash[:2]>/<hash[2:4]>/<hash><ext>) so a single directory
+// never holds more than a few hundred files. It is relative to whatever
+// root the active storage.Backend uses.
+func casBlobKey(userID, hash, ext string) string {
+	return filepath.Join(userID, hash[:2], hash[2:4], hash+ext)
+}
+
+// externalLinkForAsset mints a fresh link to asset's blob from backend,
+// deriving the same content-addressable key CreateAsset stored it under.
+// It returns "" when the backend has no client-reachable URL (e.g. fs) or
+// the asset has no recorded hash. Computing this at read time, instead of
+// persisting backend.URL's return value on the document, keeps the S3
+// backend's presigned URLs (which expire after presignExpiry) from going
+// stale in the index.
+func externalLinkForAsset(backend storage.Backend, userID string, asset *models.Asset) string {
+	if asset.ContentSHA256 == "" {
+		return ""
 	}
+	key := casBlobKey(userID, asset.ContentSHA256, filepath.Ext(asset.OriginalFilename))
+	return backend.URL(key)
+}
+
+func CreateAsset(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	backend := middleware.GetStorageBackend(r)
+	user := middleware.GetAuthenticatedUser(r)
 
 	r.Body = http.MaxBytesReader(w, r.Body, 10<<20)
 
@@ -43,34 +115,92 @@ func CreateAsset(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
-	date := time.Now()
 	filename := handler.Filename
+	createdAt := r.FormValue("created_at")
+	if createdAt == "" {
+		createdAt = time.Now().Format(time.RFC3339)
+	}
 	asset := models.Asset{
 		ID:               r.FormValue("id"),
 		UserID:           user.ID,
 		OriginalFilename: handler.Filename,
-		CreatedAt:        r.FormValue("created_at"),
+		CreatedAt:        createdAt,
 	}
-	docPath := filepath.Join(assetDirectory, user.ID, date.Format("2006/01/02"), asset.ID)
-	if err := os.MkdirAll(docPath, os.ModePerm); err != nil {
-		log.Fatalf("Error creating asset directory: %s", err)
+
+	incomingDir := filepath.Join(assetDirectory, user.ID, "_incoming")
+	if err := os.MkdirAll(incomingDir, os.ModePerm); err != nil {
+		log.Fatalf("Error creating incoming directory: %s", err)
 	}
-	filepath := filepath.Join(docPath, filename)
 
-	outFile, err := os.Create(filepath)
+	tempFile, err := os.CreateTemp(incomingDir, "upload-*")
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Unable to create file: %v", err), http.StatusInternalServerError)
 		return
 	}
-	defer outFile.Close()
+	tempPath := tempFile.Name()
 
-	_, err = io.Copy(outFile, file)
+	hasher := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(tempFile, hasher), limit: uploadMaxBytes}
+	written, err := io.Copy(counter, file)
+	tempFile.Close()
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Unable to save file: %v", err), http.StatusInternalServerError)
+		os.Remove(tempPath)
+		http.Error(w, fmt.Sprintf("Unable to save file: %v", err), http.StatusRequestEntityTooLarge)
 		return
 	}
 
-	asset.ImagePath = filepath
+	asset.ContentSHA256 = hex.EncodeToString(hasher.Sum(nil))
+	asset.Size = written
+
+	key := casBlobKey(user.ID, asset.ContentSHA256, filepath.Ext(filename))
+	ctx := r.Context()
+
+	if existingBlob, err := backend.Get(ctx, key); err == nil {
+		// Blob already stored under this hash (a re-upload of the same
+		// bytes, possibly from another device): drop the new copy and
+		// reuse the existing one, but still record a new logical asset
+		// row so each upload keeps its own id/filename/timestamp.
+		existingBlob.Close()
+		os.Remove(tempPath)
+
+		if existing, err := findAssetByHash(esClient, user.ID, asset.ContentSHA256); err != nil {
+			log.Printf("Error checking for duplicate asset: %s", err)
+		} else if existing != nil {
+			asset.Width, asset.Height = existing.Width, existing.Height
+			asset.MimeType = existing.MimeType
+			asset.PHash = existing.PHash
+			asset.BlurHash = existing.BlurHash
+			asset.DominantColor = existing.DominantColor
+			asset.Thumbnails = existing.Thumbnails
+			asset.ImagePath = existing.ImagePath
+			asset.InternalPath = existing.InternalPath
+		}
+	} else {
+		uploaded, err := os.Open(tempPath)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unable to reopen upload: %v", err), http.StatusInternalServerError)
+			return
+		}
+		internalPath, err := backend.Put(ctx, key, uploaded)
+		uploaded.Close()
+		os.Remove(tempPath)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unable to store file: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		asset.InternalPath = internalPath
+
+		// The image pipeline (dimensions, PHash, BlurHash, thumbnails)
+		// needs to read the blob back off local disk; that's only
+		// possible today when the fs backend put it there directly.
+		if _, ok := backend.(*storage.FSBackend); ok {
+			asset.ImagePath = internalPath
+			if err := runImagePipeline(&asset, internalPath, filepath.Dir(internalPath)); err != nil {
+				log.Printf("Error processing image %s: %s", internalPath, err)
+			}
+		}
+	}
 
 	assetBytes, _ := json.Marshal(asset)
 	res, err := esClient.Index("assets-"+user.ID, bytes.NewReader(assetBytes), esClient.Index.WithDocumentID(asset.ID))
@@ -93,66 +223,263 @@ func CreateAsset(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(asset)
 }
 
-func GetAsset(w http.ResponseWriter, r *http.Request) {
-	esClient := middleware.GetElasticsearchClient(r)
-	user, err := AuthenticateUserFromToken(r, esClient)
+// findAssetByHash looks up an existing asset for the user with the given
+// content_sha256, so re-uploads of the same bytes don't create duplicates.
+func findAssetByHash(esClient *elasticsearch.Client, userID, hash string) (*models.Asset, error) {
+	queryBody, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{
+				"content_sha256.keyword": map[string]interface{}{
+					"value": hash,
+				},
+			},
+		},
+	})
 	if err != nil {
-		http.Error(w, "Failed to authenticate user", http.StatusUnauthorized)
-		return
+		return nil, err
 	}
 
-	vars := mux.Vars(r)
-	id := vars["id"]
+	res, err := esClient.Search(
+		esClient.Search.WithIndex("assets-"+userID),
+		esClient.Search.WithBody(bytes.NewReader(queryBody)),
+		esClient.Search.WithSize(1),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	hits, ok := result["hits"].(map[string]interface{})["hits"].([]interface{})
+	if !ok || len(hits) == 0 {
+		return nil, nil
+	}
 
-	res, err := esClient.Get("assets-"+user.ID, id)
+	source := hits[0].(map[string]interface{})["_source"]
+	jsonDoc, _ := json.Marshal(source)
+	var existing models.Asset
+	if err := json.Unmarshal(jsonDoc, &existing); err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// anyOtherAssetWithHash reports whether some asset other than excludeID
+// still references content_sha256 hash, so DeleteAsset can tell whether
+// it's safe to unlink the shared blob or another asset row still needs it.
+func anyOtherAssetWithHash(esClient *elasticsearch.Client, userID, hash, excludeID string) (bool, error) {
+	queryBody, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []interface{}{
+					map[string]interface{}{
+						"term": map[string]interface{}{"content_sha256.keyword": hash},
+					},
+				},
+				"must_not": []interface{}{
+					map[string]interface{}{
+						"ids": map[string]interface{}{"values": []string{excludeID}},
+					},
+				},
+			},
+		},
+	})
 	if err != nil {
-		http.Error(w, "Failed to fetch document", http.StatusInternalServerError)
-		return
+		return false, err
+	}
+
+	res, err := esClient.Search(
+		esClient.Search.WithIndex("assets-"+userID),
+		esClient.Search.WithBody(bytes.NewReader(queryBody)),
+		esClient.Search.WithSize(1),
+	)
+	if err != nil {
+		return false, err
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		http.Error(w, "Document not found", http.StatusNotFound)
-		return
+		return false, nil
 	}
 
 	var result map[string]interface{}
 	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		http.Error(w, "Error parsing response", http.StatusInternalServerError)
-		return
+		return false, err
 	}
 
-	// Extract and format the documents
-	hits := result["hits"].(map[string]interface{})["hits"].([]interface{})
-	var documents []models.Asset
-	for _, hit := range hits {
-		doc := hit.(map[string]interface{})["_source"]
-		jsonDoc, _ := json.Marshal(doc)
-		var document models.Asset
-		json.Unmarshal(jsonDoc, &document)
-		documents = append(documents, document)
+	hits, ok := result["hits"].(map[string]interface{})["hits"].([]interface{})
+	return ok && len(hits) > 0, nil
+}
+
+// runImagePipeline decodes the image written at imagePath and populates
+// asset with its dimensions, MIME type, perceptual hash, BlurHash placeholder,
+// dominant color, EXIF-derived metadata, and a set of resized thumbnails
+// written alongside the original under docPath. It is best-effort: a decode
+// failure (e.g. an unsupported format such as HEIC) leaves those fields
+// empty rather than failing the upload.
+func runImagePipeline(asset *models.Asset, imagePath, docPath string) error {
+	f, err := os.Open(imagePath)
+	if err != nil {
+		return fmt.Errorf("opening image: %w", err)
 	}
+	defer f.Close()
 
-	if len(documents) == 0 {
+	img, format, err := image.Decode(f)
+	if err != nil {
+		return fmt.Errorf("decoding image: %w", err)
+	}
+
+	bounds := img.Bounds()
+	asset.Width = bounds.Dx()
+	asset.Height = bounds.Dy()
+	asset.MimeType = "image/" + format
+
+	if exifInfo, err := metadata.Extract(imagePath); err != nil {
+		log.Printf("Error extracting EXIF for %s: %s", imagePath, err)
+	} else {
+		if !exifInfo.TakenAt.IsZero() {
+			asset.TakenAt = exifInfo.TakenAt.Format(time.RFC3339)
+		}
+		if exifInfo.HasGPS {
+			asset.Latitude = exifInfo.Latitude
+			asset.Longitude = exifInfo.Longitude
+			asset.Location = &models.GeoPoint{Lat: exifInfo.Latitude, Lon: exifInfo.Longitude}
+		}
+		asset.CameraMake = exifInfo.CameraMake
+		asset.CameraModel = exifInfo.CameraModel
+		asset.Orientation = exifInfo.Orientation
+	}
+
+	asset.PHash = imaging.DHash(img)
+	asset.DominantColor = imaging.DominantColor(img)
+
+	blurHashSrc := imaging.Thumbnail(img, 64)
+	blurHash, err := imaging.EncodeBlurHash(blurHashSrc, blurHashComponentsX, blurHashComponentsY)
+	if err != nil {
+		log.Printf("Error computing blurhash for %s: %s", imagePath, err)
+	} else {
+		asset.BlurHash = blurHash
+	}
+
+	thumbsDir := filepath.Join(docPath, "thumbs")
+	if err := os.MkdirAll(thumbsDir, os.ModePerm); err != nil {
+		return fmt.Errorf("creating thumbnail directory: %w", err)
+	}
+
+	for _, edge := range thumbnailEdges {
+		thumb := imaging.Thumbnail(img, edge)
+		// docPath (and thumbsDir under it) is shared by every blob whose
+		// hash collides on the CAS sharding prefix (see casBlobKey), so
+		// the filename must carry the full hash to avoid one blob's
+		// thumbnail clobbering another's.
+		thumbPath := filepath.Join(thumbsDir, fmt.Sprintf("%s_%d.jpg", asset.ContentSHA256, edge))
+		if err := imaging.SaveJPEG(thumb, thumbPath, 85); err != nil {
+			log.Printf("Error writing %dpx thumbnail for %s: %s", edge, imagePath, err)
+			continue
+		}
+		tb := thumb.Bounds()
+		asset.Thumbnails = append(asset.Thumbnails, models.Thumbnail{
+			Size:   fmt.Sprintf("%d", edge),
+			Path:   thumbPath,
+			Width:  tb.Dx(),
+			Height: tb.Dy(),
+		})
+	}
+
+	return nil
+}
+
+func GetAsset(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	backend := middleware.GetStorageBackend(r)
+	user := middleware.GetAuthenticatedUser(r)
+
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	asset, err := fetchAssetByID(esClient, user.ID, id)
+	if err != nil {
+		http.Error(w, "Failed to fetch document", http.StatusInternalServerError)
+		return
+	}
+	if asset == nil {
 		http.Error(w, "Asset not found", http.StatusNotFound)
 		return
 	}
 
+	if asset.UserID != user.ID {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	// As with GetAssetImage, prefer handing the client a link straight to
+	// the object store over serving the metadata document, when the
+	// storage backend gives us one.
+	if asset.ImagePath == "" {
+		if link := externalLinkForAsset(backend, user.ID, asset); link != "" {
+			http.Redirect(w, r, link, http.StatusFound)
+			return
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(documents[0])
+	json.NewEncoder(w).Encode(asset)
 }
 
-func UpdateAsset(w http.ResponseWriter, r *http.Request) {
-	esClient := middleware.GetElasticsearchClient(r)
-	user, err := AuthenticateUserFromToken(r, esClient)
+// fetchAssetByID fetches a single asset document by id from userID's index,
+// returning a nil asset (not an error) when it does not exist.
+func fetchAssetByID(esClient *elasticsearch.Client, userID, id string) (*models.Asset, error) {
+	res, err := esClient.Get("assets-"+userID, id)
 	if err != nil {
-		http.Error(w, "Failed to authenticate user", http.StatusUnauthorized)
-		return
+		return nil, err
 	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	source, ok := result["_source"]
+	if !ok {
+		return nil, nil
+	}
+
+	jsonDoc, _ := json.Marshal(source)
+	var asset models.Asset
+	if err := json.Unmarshal(jsonDoc, &asset); err != nil {
+		return nil, err
+	}
+	return &asset, nil
+}
+
+func UpdateAsset(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	user := middleware.GetAuthenticatedUser(r)
 
 	vars := mux.Vars(r)
 	id := vars["id"]
 
+	if owned, err := assetOwnedByUser(esClient, user.ID, id); err != nil {
+		http.Error(w, "Failed to fetch Asset", http.StatusInternalServerError)
+		return
+	} else if !owned {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
 	var doc models.UpdateAsset
 	if err := json.NewDecoder(r.Body).Decode(&doc); err != nil {
 		http.Error(w, "Invalid request payload", http.StatusBadRequest)
@@ -175,11 +502,23 @@ func UpdateAsset(w http.ResponseWriter, r *http.Request) {
 
 func DeleteAsset(w http.ResponseWriter, r *http.Request) {
 	esClient := middleware.GetElasticsearchClient(r)
+	backend := middleware.GetStorageBackend(r)
+	user := middleware.GetAuthenticatedUser(r)
 
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	res, err := esClient.Delete("Assets", id)
+	asset, err := fetchAssetByID(esClient, user.ID, id)
+	if err != nil {
+		http.Error(w, "Failed to fetch Asset", http.StatusInternalServerError)
+		return
+	}
+	if asset == nil || asset.UserID != user.ID {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	res, err := esClient.Delete("assets-"+user.ID, id)
 	if err != nil {
 		http.Error(w, "Failed to delete Asset", http.StatusInternalServerError)
 		return
@@ -191,16 +530,112 @@ func DeleteAsset(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if asset.ContentSHA256 != "" {
+		if stillReferenced, err := anyOtherAssetWithHash(esClient, user.ID, asset.ContentSHA256, id); err != nil {
+			log.Printf("Error checking blob refcount for %s: %s", asset.ContentSHA256, err)
+		} else if !stillReferenced {
+			key := casBlobKey(user.ID, asset.ContentSHA256, filepath.Ext(asset.OriginalFilename))
+			if err := backend.Delete(r.Context(), key); err != nil {
+				log.Printf("Error deleting blob %s: %s", key, err)
+			}
+			// Thumbnails live in a directory shared by every blob whose
+			// hash collides on the CAS sharding prefix (see casBlobKey),
+			// so only remove this asset's own thumbnail files, not the
+			// enclosing directory.
+			for _, thumb := range asset.Thumbnails {
+				if thumb.Path == "" {
+					continue
+				}
+				if err := os.Remove(thumb.Path); err != nil && !os.IsNotExist(err) {
+					log.Printf("Error deleting thumbnail %s: %s", thumb.Path, err)
+				}
+			}
+		}
+	}
+
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message": "Asset deleted successfully"}`))
 }
 
+// assetOwnedByUser reports whether the asset with id exists in userID's
+// index, used to 403 cross-user update attempts before mutating.
+func assetOwnedByUser(esClient *elasticsearch.Client, userID, id string) (bool, error) {
+	res, err := esClient.Get("assets-"+userID, id)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	return !res.IsError(), nil
+}
+
+// ListAssets answers GET /assets with the caller's own assets, newest first.
+// It accepts `?limit=` (default listDefaultSize), `?cursor=` (an opaque
+// search_after cursor from a previous response's next_cursor), `?sort=asc`
+// to reverse the default descending order, and `?q=` (a simple_query_string
+// over filename and labels). The response is `{items, next_cursor}`;
+// next_cursor is empty once the last page has been reached.
 func ListAssets(w http.ResponseWriter, r *http.Request) {
 	esClient := middleware.GetElasticsearchClient(r)
+	user := middleware.GetAuthenticatedUser(r)
+
+	query := r.URL.Query()
+
+	size := listDefaultSize
+	if raw := query.Get("limit"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			size = n
+		}
+	}
+
+	order := "desc"
+	if query.Get("sort") == "asc" {
+		order = "asc"
+	}
+
+	boolQuery := map[string]interface{}{
+		"filter": []interface{}{},
+	}
+	if q := query.Get("q"); q != "" {
+		boolQuery["must"] = []interface{}{
+			map[string]interface{}{
+				"simple_query_string": map[string]interface{}{
+					"query":  q,
+					"fields": []string{"original_filename", "labels"},
+				},
+			},
+		}
+	}
+
+	body := map[string]interface{}{
+		"size": size,
+		"sort": []interface{}{
+			map[string]interface{}{"created_at": order},
+			map[string]interface{}{"_id": order},
+		},
+		"query": map[string]interface{}{
+			"bool": boolQuery,
+		},
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		searchAfter, err := decodeSearchAfterCursor(cursor)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid cursor: %s", err), http.StatusBadRequest)
+			return
+		}
+		body["search_after"] = searchAfter
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, "Failed to build list request", http.StatusInternalServerError)
+		return
+	}
 
 	res, err := esClient.Search(
-		esClient.Search.WithIndex("documents"),
-		esClient.Search.WithSize(100), // Limit the number of results (adjust as needed)
+		esClient.Search.WithIndex("assets-"+user.ID),
+		esClient.Search.WithBody(bytes.NewReader(bodyBytes)),
 	)
 	if err != nil {
 		http.Error(w, "Failed to fetch documents", http.StatusInternalServerError)
@@ -219,17 +654,29 @@ func ListAssets(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Extract and format the documents
-	hits := result["hits"].(map[string]interface{})["hits"].([]interface{})
-	var documents []models.Asset
-	for _, hit := range hits {
-		doc := hit.(map[string]interface{})["_source"]
-		jsonDoc, _ := json.Marshal(doc)
-		var document models.Asset
-		json.Unmarshal(jsonDoc, &document)
-		documents = append(documents, document)
+	hitsRaw, _ := result["hits"].(map[string]interface{})["hits"].([]interface{})
+	items := make([]models.Asset, 0, len(hitsRaw))
+	var nextCursor string
+	for i, hit := range hitsRaw {
+		h := hit.(map[string]interface{})
+		jsonDoc, _ := json.Marshal(h["_source"])
+		var asset models.Asset
+		json.Unmarshal(jsonDoc, &asset)
+		items = append(items, asset)
+
+		if i == len(hitsRaw)-1 {
+			if sortValues, ok := h["sort"].([]interface{}); ok {
+				nextCursor = encodeSearchAfterCursor(sortValues)
+			}
+		}
+	}
+	if len(hitsRaw) < size {
+		nextCursor = ""
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(documents)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":       items,
+		"next_cursor": nextCursor,
+	})
 }
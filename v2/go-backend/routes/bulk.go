@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"go-backend/middleware"
+	"go-backend/models"
+	"go-backend/utils"
+
+	"github.com/elastic/go-elasticsearch/v8/esutil"
+)
+
+const (
+	bulkFlushBytes    = 5 << 20
+	bulkFlushInterval = 0 // not passed to the indexer, which flushes at bulkFlushBytes, on esutil's default interval, and on Close
+	bulkMaxLineBytes  = 10 << 20
+)
+
+// BulkCreateAssets accepts a newline-delimited JSON body of Asset documents
+// (matching Elasticsearch's own `_bulk` line format, one document per line)
+// and forwards them to an esutil.BulkIndexer for the authenticated user's
+// index. Every document is stamped with the caller's user ID regardless of
+// what the body contains, and is assigned a UUIDv7 ID if it doesn't have one.
+func BulkCreateAssets(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	user := middleware.GetAuthenticatedUser(r)
+	ctx := r.Context()
+
+	bi, err := esutil.NewBulkIndexer(esutil.BulkIndexerConfig{
+		Client:     esClient,
+		Index:      "assets-" + user.ID,
+		FlushBytes: bulkFlushBytes,
+	})
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create bulk indexer: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	var parseFailures int
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 64*1024), bulkMaxLineBytes)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var asset models.Asset
+		if err := json.Unmarshal(line, &asset); err != nil {
+			log.Printf("Skipping malformed bulk line: %s", err)
+			parseFailures++
+			continue
+		}
+		asset.UserID = user.ID
+		if asset.ID == "" {
+			asset.ID = utils.GenerateUUIDv7("ast")
+		}
+
+		docBytes, err := json.Marshal(asset)
+		if err != nil {
+			parseFailures++
+			continue
+		}
+
+		err = bi.Add(ctx, esutil.BulkIndexerItem{
+			Action:     "index",
+			DocumentID: asset.ID,
+			Body:       bytes.NewReader(docBytes),
+			OnFailure: func(ctx context.Context, item esutil.BulkIndexerItem, res esutil.BulkIndexerResponseItem, err error) {
+				if err != nil {
+					log.Printf("Bulk index failure for %s: %s", item.DocumentID, err)
+				} else {
+					log.Printf("Bulk index failure for %s: %s", item.DocumentID, res.Error.Reason)
+				}
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to queue asset %s for bulk indexing: %s", asset.ID, err)
+			parseFailures++
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		http.Error(w, fmt.Sprintf("Error reading request body: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	if err := bi.Close(ctx); err != nil {
+		http.Error(w, fmt.Sprintf("Bulk indexer failed to flush: %s", err), http.StatusInternalServerError)
+		return
+	}
+
+	stats := bi.Stats()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"indexed": stats.NumIndexed,
+		"failed":  stats.NumFailed + uint64(parseFailures),
+	})
+}
@@ -0,0 +1,265 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go-backend/middleware"
+	"go-backend/models"
+)
+
+const searchDefaultSize = 50
+
+// SearchAssets answers GET /assets/search with a faceted query over the
+// caller's asset index: full-text `q` over filename/labels/metadata, a
+// multi-value `label` term filter, a `from`/`to` range over `taken_at` (the
+// EXIF capture date), a `bbox` or `near`+`radius` geo filter over the EXIF
+// `location`, a `camera` filter over `camera_model`, and a `source` filter.
+// Alongside hits it returns a date_histogram timeline, a labels terms facet,
+// and a geohash_grid for map clustering. Deep pagination uses `search_after`
+// via an opaque `cursor` built from the last hit's sort values.
+func SearchAssets(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	user := middleware.GetAuthenticatedUser(r)
+
+	query := r.URL.Query()
+
+	boolQuery := map[string]interface{}{
+		"must":   []interface{}{},
+		"filter": []interface{}{},
+	}
+
+	if q := query.Get("q"); q != "" {
+		boolQuery["must"] = append(boolQuery["must"].([]interface{}), map[string]interface{}{
+			"simple_query_string": map[string]interface{}{
+				"query":  q,
+				"fields": []string{"original_filename", "labels", "metadata.*"},
+			},
+		})
+	}
+
+	if labels := query["label"]; len(labels) > 0 {
+		boolQuery["filter"] = append(boolQuery["filter"].([]interface{}), map[string]interface{}{
+			"terms": map[string]interface{}{"labels": labels},
+		})
+	}
+
+	if source := query.Get("source"); source != "" {
+		boolQuery["filter"] = append(boolQuery["filter"].([]interface{}), map[string]interface{}{
+			"term": map[string]interface{}{"source": source},
+		})
+	}
+
+	if from, to := query.Get("from"), query.Get("to"); from != "" || to != "" {
+		dateRange := map[string]interface{}{}
+		if from != "" {
+			dateRange["gte"] = from
+		}
+		if to != "" {
+			dateRange["lte"] = to
+		}
+		boolQuery["filter"] = append(boolQuery["filter"].([]interface{}), map[string]interface{}{
+			"range": map[string]interface{}{"taken_at": dateRange},
+		})
+	}
+
+	if camera := query.Get("camera"); camera != "" {
+		boolQuery["filter"] = append(boolQuery["filter"].([]interface{}), map[string]interface{}{
+			"term": map[string]interface{}{"camera_model": camera},
+		})
+	}
+
+	if geoFilter, err := geoFilterFromParams(query); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if geoFilter != nil {
+		boolQuery["filter"] = append(boolQuery["filter"].([]interface{}), geoFilter)
+	}
+
+	size := searchDefaultSize
+	if raw := query.Get("limit"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			size = n
+		}
+	}
+
+	body := map[string]interface{}{
+		"size": size,
+		"sort": []interface{}{
+			map[string]interface{}{"created_at": "desc"},
+			map[string]interface{}{"_id": "desc"},
+		},
+		"query": map[string]interface{}{
+			"bool": boolQuery,
+		},
+		"aggs": map[string]interface{}{
+			"timeline": map[string]interface{}{
+				"date_histogram": map[string]interface{}{
+					"field":             "created_at",
+					"calendar_interval": "month",
+				},
+			},
+			"labels": map[string]interface{}{
+				"terms": map[string]interface{}{
+					"field": "labels",
+					"size":  50,
+				},
+			},
+			"map": map[string]interface{}{
+				"geohash_grid": map[string]interface{}{
+					"field":     "location",
+					"precision": 5,
+				},
+			},
+		},
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		searchAfter, err := decodeSearchAfterCursor(cursor)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid cursor: %s", err), http.StatusBadRequest)
+			return
+		}
+		body["search_after"] = searchAfter
+	}
+
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, "Failed to build search request", http.StatusInternalServerError)
+		return
+	}
+
+	res, err := esClient.Search(
+		esClient.Search.WithIndex("assets-"+user.ID),
+		esClient.Search.WithBody(bytes.NewReader(bodyBytes)),
+	)
+	if err != nil {
+		http.Error(w, "Failed to search assets", http.StatusInternalServerError)
+		return
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		http.Error(w, fmt.Sprintf("Search failed: %s", res.String()), http.StatusInternalServerError)
+		return
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		http.Error(w, "Error parsing search response", http.StatusInternalServerError)
+		return
+	}
+
+	hitsRaw, _ := result["hits"].(map[string]interface{})["hits"].([]interface{})
+	items := make([]models.Asset, 0, len(hitsRaw))
+	var nextCursor string
+	for i, hit := range hitsRaw {
+		h := hit.(map[string]interface{})
+		jsonDoc, _ := json.Marshal(h["_source"])
+		var asset models.Asset
+		json.Unmarshal(jsonDoc, &asset)
+		items = append(items, asset)
+
+		if i == len(hitsRaw)-1 {
+			if sortValues, ok := h["sort"].([]interface{}); ok {
+				nextCursor = encodeSearchAfterCursor(sortValues)
+			}
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":        items,
+		"next_cursor":  nextCursor,
+		"aggregations": result["aggregations"],
+	})
+}
+
+// geoFilterFromParams builds a geo_bounding_box filter from `bbox`
+// (min_lon,min_lat,max_lon,max_lat) or a geo_distance filter from `near`
+// (lat,lon) and `radius` (e.g. "10km", default "10km").
+func geoFilterFromParams(query map[string][]string) (map[string]interface{}, error) {
+	get := func(key string) string {
+		if v, ok := query[key]; ok && len(v) > 0 {
+			return v[0]
+		}
+		return ""
+	}
+
+	if bbox := get("bbox"); bbox != "" {
+		parts := strings.Split(bbox, ",")
+		if len(parts) != 4 {
+			return nil, fmt.Errorf("bbox must be minLon,minLat,maxLon,maxLat")
+		}
+		coords := make([]float64, 4)
+		for i, p := range parts {
+			v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+			if err != nil {
+				return nil, fmt.Errorf("bbox must contain numeric coordinates")
+			}
+			coords[i] = v
+		}
+		return map[string]interface{}{
+			"geo_bounding_box": map[string]interface{}{
+				"location": map[string]interface{}{
+					"top_left":     map[string]interface{}{"lat": coords[3], "lon": coords[0]},
+					"bottom_right": map[string]interface{}{"lat": coords[1], "lon": coords[2]},
+				},
+			},
+		}, nil
+	}
+
+	if near := get("near"); near != "" {
+		parts := strings.Split(near, ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("near must be lat,lon")
+		}
+		lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("near must contain numeric coordinates")
+		}
+		lon, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("near must contain numeric coordinates")
+		}
+
+		radius := get("radius")
+		if radius == "" {
+			radius = "10km"
+		}
+
+		return map[string]interface{}{
+			"geo_distance": map[string]interface{}{
+				"distance": radius,
+				"location": map[string]interface{}{"lat": lat, "lon": lon},
+			},
+		}, nil
+	}
+
+	return nil, nil
+}
+
+func encodeSearchAfterCursor(sortValues []interface{}) string {
+	raw, err := json.Marshal(sortValues)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeSearchAfterCursor(cursor string) ([]interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, err
+	}
+	var values []interface{}
+	if err := json.Unmarshal(raw, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
@@ -0,0 +1,371 @@
+package routes
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"go-backend/middleware"
+	"go-backend/models"
+	"go-backend/storage"
+	"go-backend/utils"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/gorilla/mux"
+)
+
+// uploadSessionTTL is how long an upload session may sit idle before a
+// client is expected to treat it as expired and start over. Nothing
+// currently reaps expired sessions or their temp files; ExpiresAt is
+// recorded so a future cleanup job has something to query on.
+const uploadSessionTTL = 24 * time.Hour
+
+var contentRangePattern = regexp.MustCompile(`^bytes (\d+)-(\d+)/(\d+)$`)
+
+// createUploadRequest is the POST /assets/uploads body.
+type createUploadRequest struct {
+	Filename string `json:"filename"`
+	Size     int64  `json:"size"`
+	SHA256   string `json:"sha256,omitempty"`
+}
+
+// CreateUploadSession answers POST /assets/uploads: it reserves a temp file
+// and an upload_id a client will PATCH chunks into via Content-Range, then
+// finalize with CompleteUpload.
+func CreateUploadSession(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	user := middleware.GetAuthenticatedUser(r)
+
+	var req createUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("Invalid request payload: %s", err), http.StatusBadRequest)
+		return
+	}
+	if req.Filename == "" || req.Size <= 0 {
+		http.Error(w, "filename and a positive size are required", http.StatusBadRequest)
+		return
+	}
+
+	id := utils.GenerateUUIDv7("upl")
+
+	tempDir := filepath.Join(assetDirectory, user.ID, "_incoming", "uploads")
+	if err := os.MkdirAll(tempDir, os.ModePerm); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to create upload directory: %v", err), http.StatusInternalServerError)
+		return
+	}
+	tempPath := filepath.Join(tempDir, id)
+	f, err := os.Create(tempPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to reserve upload file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	f.Close()
+
+	now := time.Now()
+	session := models.UploadSession{
+		ID:             id,
+		UserID:         user.ID,
+		Filename:       req.Filename,
+		TotalSize:      req.Size,
+		Offset:         0,
+		ExpectedSHA256: req.SHA256,
+		TempPath:       tempPath,
+		Status:         "pending",
+		CreatedAt:      now.Format(time.RFC3339),
+		ExpiresAt:      now.Add(uploadSessionTTL).Format(time.RFC3339),
+	}
+
+	if err := indexUploadSession(esClient, &session); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to create upload session: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"upload_id":  session.ID,
+		"offset":     session.Offset,
+		"expires_at": session.ExpiresAt,
+	})
+}
+
+// AppendUploadChunk answers PATCH /assets/uploads/{upload_id}. It accepts a
+// byte range via a `Content-Range: bytes X-Y/Z` header, writes the request
+// body at that offset in the session's temp file, and returns the new
+// committed offset so the client knows where to resume from if interrupted.
+func AppendUploadChunk(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	user := middleware.GetAuthenticatedUser(r)
+
+	uploadID := mux.Vars(r)["upload_id"]
+
+	session, err := fetchUploadSession(esClient, user.ID, uploadID)
+	if err != nil {
+		http.Error(w, "Failed to fetch upload session", http.StatusInternalServerError)
+		return
+	}
+	if session == nil || session.UserID != user.ID {
+		http.Error(w, "Upload session not found", http.StatusNotFound)
+		return
+	}
+	if session.Status != "pending" {
+		http.Error(w, "Upload session is already complete", http.StatusConflict)
+		return
+	}
+
+	start, end, total, err := parseContentRange(r.Header.Get("Content-Range"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if total != session.TotalSize {
+		http.Error(w, "Content-Range total does not match the session's declared size", http.StatusBadRequest)
+		return
+	}
+	if start != session.Offset {
+		http.Error(w, fmt.Sprintf("Expected chunk starting at offset %d", session.Offset), http.StatusConflict)
+		return
+	}
+
+	f, err := os.OpenFile(session.TempPath, os.O_WRONLY, 0644)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to open upload file: %v", err), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Seek(start, io.SeekStart); err != nil {
+		http.Error(w, fmt.Sprintf("Unable to seek upload file: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	written, err := io.Copy(f, io.LimitReader(r.Body, end-start+1))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to write chunk: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	session.Offset = start + written
+	if err := updateUploadSessionOffset(esClient, session); err != nil {
+		http.Error(w, fmt.Sprintf("Failed to persist upload progress: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"offset": session.Offset})
+}
+
+// CompleteUpload answers POST /assets/uploads/{upload_id}/complete. It
+// verifies every byte has been received, checks the assembled file's
+// SHA-256 against the hash recorded when the session was created (if any),
+// stores it through the same content-addressable path CreateAsset uses,
+// and indexes the resulting Asset.
+func CompleteUpload(w http.ResponseWriter, r *http.Request) {
+	esClient := middleware.GetElasticsearchClient(r)
+	backend := middleware.GetStorageBackend(r)
+	user := middleware.GetAuthenticatedUser(r)
+
+	uploadID := mux.Vars(r)["upload_id"]
+
+	session, err := fetchUploadSession(esClient, user.ID, uploadID)
+	if err != nil {
+		http.Error(w, "Failed to fetch upload session", http.StatusInternalServerError)
+		return
+	}
+	if session == nil || session.UserID != user.ID {
+		http.Error(w, "Upload session not found", http.StatusNotFound)
+		return
+	}
+	if session.Offset != session.TotalSize {
+		http.Error(w, fmt.Sprintf("Upload incomplete: received %d of %d bytes", session.Offset, session.TotalSize), http.StatusConflict)
+		return
+	}
+
+	actualHash, err := hashFile(session.TempPath)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Unable to verify upload: %v", err), http.StatusInternalServerError)
+		return
+	}
+	if session.ExpectedSHA256 != "" && session.ExpectedSHA256 != actualHash {
+		http.Error(w, "Uploaded content does not match the declared SHA-256", http.StatusConflict)
+		return
+	}
+
+	asset := models.Asset{
+		ID:               utils.GenerateUUIDv7("ast"),
+		UserID:           user.ID,
+		OriginalFilename: session.Filename,
+		CreatedAt:        time.Now().Format(time.RFC3339),
+		ContentSHA256:    actualHash,
+		Size:             session.TotalSize,
+	}
+
+	key := casBlobKey(user.ID, actualHash, filepath.Ext(session.Filename))
+	ctx := r.Context()
+
+	if existingBlob, err := backend.Get(ctx, key); err == nil {
+		existingBlob.Close()
+		os.Remove(session.TempPath)
+
+		if existing, err := findAssetByHash(esClient, user.ID, actualHash); err != nil {
+			log.Printf("Error checking for duplicate asset: %s", err)
+		} else if existing != nil {
+			asset.Width, asset.Height = existing.Width, existing.Height
+			asset.MimeType = existing.MimeType
+			asset.PHash = existing.PHash
+			asset.BlurHash = existing.BlurHash
+			asset.Thumbnails = existing.Thumbnails
+			asset.ImagePath = existing.ImagePath
+			asset.InternalPath = existing.InternalPath
+		}
+	} else {
+		uploaded, err := os.Open(session.TempPath)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unable to reopen upload: %v", err), http.StatusInternalServerError)
+			return
+		}
+		internalPath, err := backend.Put(ctx, key, uploaded)
+		uploaded.Close()
+		os.Remove(session.TempPath)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Unable to store file: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		asset.InternalPath = internalPath
+
+		if _, ok := backend.(*storage.FSBackend); ok {
+			asset.ImagePath = internalPath
+			if err := runImagePipeline(&asset, internalPath, filepath.Dir(internalPath)); err != nil {
+				log.Printf("Error processing image %s: %s", internalPath, err)
+			}
+		}
+	}
+
+	assetBytes, _ := json.Marshal(asset)
+	res, err := esClient.Index("assets-"+user.ID, bytes.NewReader(assetBytes), esClient.Index.WithDocumentID(asset.ID))
+	if err != nil {
+		http.Error(w, "Failed to create document", http.StatusInternalServerError)
+		return
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		http.Error(w, "Failed to create document", http.StatusInternalServerError)
+		return
+	}
+
+	if delRes, err := esClient.Delete("uploads-"+user.ID, session.ID); err != nil {
+		log.Printf("Error deleting completed upload session %s: %s", session.ID, err)
+	} else {
+		delRes.Body.Close()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(asset)
+}
+
+// parseContentRange parses a `Content-Range: bytes X-Y/Z` header into its
+// start, end, and total components.
+func parseContentRange(header string) (start, end, total int64, err error) {
+	match := contentRangePattern.FindStringSubmatch(header)
+	if match == nil {
+		return 0, 0, 0, fmt.Errorf("Content-Range must look like \"bytes X-Y/Z\"")
+	}
+	start, _ = strconv.ParseInt(match[1], 10, 64)
+	end, _ = strconv.ParseInt(match[2], 10, 64)
+	total, _ = strconv.ParseInt(match[3], 10, 64)
+	if end < start {
+		return 0, 0, 0, fmt.Errorf("Content-Range end must not precede start")
+	}
+	return start, end, total, nil
+}
+
+// hashFile computes the SHA-256 digest of the file at path without loading
+// it into memory all at once.
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// indexUploadSession writes a new upload session document.
+func indexUploadSession(esClient *elasticsearch.Client, session *models.UploadSession) error {
+	body, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	res, err := esClient.Index("uploads-"+session.UserID, bytes.NewReader(body), esClient.Index.WithDocumentID(session.ID))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("indexing upload session: %s", res.String())
+	}
+	return nil
+}
+
+// updateUploadSessionOffset persists session's current Offset.
+func updateUploadSessionOffset(esClient *elasticsearch.Client, session *models.UploadSession) error {
+	docBytes, _ := json.Marshal(map[string]interface{}{"offset": session.Offset})
+	res, err := esClient.Update("uploads-"+session.UserID, session.ID, bytes.NewReader([]byte(`{"doc": `+string(docBytes)+`}`)))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("updating upload session: %s", res.String())
+	}
+	return nil
+}
+
+// fetchUploadSession fetches an upload session by id from userID's
+// uploads-<user> index, returning a nil session (not an error) when it
+// does not exist.
+func fetchUploadSession(esClient *elasticsearch.Client, userID, id string) (*models.UploadSession, error) {
+	res, err := esClient.Get("uploads-"+userID, id)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, nil
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	source, ok := result["_source"]
+	if !ok {
+		return nil, nil
+	}
+
+	jsonDoc, _ := json.Marshal(source)
+	var session models.UploadSession
+	if err := json.Unmarshal(jsonDoc, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
@@ -7,22 +7,29 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"math"
 	"mime/multipart"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go-backend/models"
 )
 
 type Asset models.Asset
 
+const maxUploadRetries = 3
+
 func main() {
 	token := flag.String("token", "", "The token for the user")
 	path := flag.String("path", "", "The path to the images directory")
 	apiEndpoint := flag.String("url", "http://localhost:8080/assets", "The API URL for asset creation")
 	source := flag.String("source", "", "The source of the images")
+	concurrency := flag.Int("concurrency", 4, "Number of concurrent uploads")
 
 	flag.Parse()
 
@@ -44,48 +51,89 @@ func main() {
 
 	folderPath := *path
 
-	log.Printf("Scanning folder: %s", folderPath)
+	log.Printf("Scanning folder: %s with %d workers", folderPath, *concurrency)
 
-	// Walk through the directory and process each file
-	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return fmt.Errorf("error accessing path %q: %v", path, err)
-		}
+	indexed, skipped, failed := uploadFolder(folderPath, *apiEndpoint, *token, *source, *concurrency)
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
+	log.Printf("Summary: indexed=%d skipped=%d failed=%d", indexed, skipped, failed)
+}
 
-		// Check if the file is an image based on its extension
-		if !isImageFile(path) {
-			fmt.Printf("Skipping non-image file: %s\n", path)
+// uploadFolder pipelines file discovery and upload: a single walker feeds
+// image paths to a pool of concurrency workers, each of which reads the
+// file's metadata and uploads it, retrying any failed upload with
+// exponential backoff.
+func uploadFolder(folderPath, apiEndpoint, token, source string, concurrency int) (indexed, skipped, failed int64) {
+	paths := make(chan string, concurrency*2)
+
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return fmt.Errorf("error accessing path %q: %v", path, err)
+			}
+			if info.IsDir() {
+				return nil
+			}
+			if !isImageFile(path) {
+				atomic.AddInt64(&skipped, 1)
+				fmt.Fprintf(os.Stderr, "Skipping non-image file: %s\n", path)
+				return nil
+			}
+			paths <- path
 			return nil
-		}
-		// Attempt to read metadata from meta.json
-		metaData, err := readMetaJSON(filepath.Dir(path))
-		if err != nil {
-			log.Printf("Error reading metadata for %s: %s", path, err)
-		}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				if err := uploadOneWithRetry(apiEndpoint, path, source, token); err != nil {
+					atomic.AddInt64(&failed, 1)
+					fmt.Fprintf(os.Stderr, "FAILED %s: %v\n", path, err)
+					continue
+				}
+				atomic.AddInt64(&indexed, 1)
+				fmt.Fprintf(os.Stderr, "OK %s\n", path)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if walkErr != nil {
+		fmt.Fprintf(os.Stderr, "Error processing folder: %v\n", walkErr)
+	}
+
+	return indexed, skipped, failed
+}
 
-		assetFields := map[string]string{
-			"id":     metaData["id"].(string),
-			"source": *source,
-		}
+func uploadOneWithRetry(apiEndpoint, path, source, token string) error {
+	metaData, err := readMetaJSON(filepath.Dir(path))
+	if err != nil {
+		log.Printf("Error reading metadata for %s: %s", path, err)
+	}
+
+	id, _ := metaData["id"].(string)
+	assetFields := map[string]string{
+		"id":     id,
+		"source": source,
+	}
 
-		// Upload the image
-		fmt.Printf("Uploading file: %s\n", path)
-		if err := uploadFile(*apiEndpoint, path, assetFields, *token); err != nil {
-			fmt.Printf("Failed to upload file %s: %v\n", path, err)
+	var lastErr error
+	for attempt := 0; attempt < maxUploadRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(math.Pow(2, float64(attempt))) * time.Second)
+		}
+		if err := uploadFile(apiEndpoint, path, assetFields, token); err != nil {
+			lastErr = err
+			continue
 		}
 		return nil
-	})
-
-	if err != nil {
-		log.Fatalf("Error processing folder: %s", err)
 	}
-
-	log.Println("Processing completed!")
+	return lastErr
 }
 
 func isImageFile(path string) bool {
@@ -174,11 +222,10 @@ func uploadFile(url string, filePath string, assetFields map[string]string, toke
 	defer resp.Body.Close()
 
 	// Check the response status
-	if resp.StatusCode != http.StatusOK {
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		respBody, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("server returned non-OK status: %s, body: %s", resp.Status, string(respBody))
 	}
 
-	fmt.Printf("Successfully uploaded file: %s\n", filePath)
 	return nil
 }
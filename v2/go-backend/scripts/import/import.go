@@ -2,71 +2,244 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"io/fs"
 	"log"
+	"math"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"go-backend/models"
 )
 
 type Asset models.Asset
 
+const maxUploadRetries = 3
+
 func main() {
-	// Initialize Elasticsearch client
-	var err error
+	folderPath := flag.String("path", "./images", "The path to the images directory")
+	apiURL := flag.String("url", "http://localhost:8080/assets/_bulk", "The bulk asset API URL")
+	token := flag.String("token", "", "The token for the user")
+	concurrency := flag.Int("concurrency", 4, "Number of concurrent metadata workers")
+	batchSize := flag.Int("batch-size", 100, "Number of assets to send per bulk call")
+	flag.Parse()
 
-	// Folder to scan
-	folderPath := "./images"
-	if len(os.Args) > 1 {
-		folderPath = os.Args[1]
+	if flag.NArg() > 0 {
+		*folderPath = flag.Arg(0)
 	}
 
-	log.Printf("Scanning folder: %s", folderPath)
-	err = processFolder(folderPath)
-	if err != nil {
+	if *token == "" {
+		fmt.Println("Error: token is required.")
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	log.Printf("Scanning folder: %s", *folderPath)
+
+	if err := processFolder(*folderPath, *apiURL, *token, *concurrency, *batchSize); err != nil {
 		log.Fatalf("Error processing folder: %s", err)
 	}
 
 	log.Println("Processing completed!")
 }
 
-func processFolder(folderPath string) error {
-	// Walk through the folder
-	return filepath.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
+// processFolder walks folderPath, pipelining discovery, metadata/hash
+// reading, and bulk upload: a single walker feeds image paths to a pool of
+// concurrency workers, which build Asset documents that are batched
+// batchSize-at-a-time into calls to the server's /assets/_bulk endpoint.
+func processFolder(folderPath, apiURL, token string, concurrency, batchSize int) error {
+	paths := make(chan string, concurrency*2)
+	assets := make(chan Asset, concurrency*2)
+
+	var walkErr error
+	go func() {
+		defer close(paths)
+		walkErr = filepath.WalkDir(folderPath, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return fmt.Errorf("error accessing file %s: %w", path, err)
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if !isImageFile(path) {
+				return nil
+			}
+			paths <- path
+			return nil
+		})
+	}()
+
+	var skipped int64
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range paths {
+				asset, err := buildAsset(path)
+				if err != nil {
+					log.Printf("Error reading %s: %s", path, err)
+					atomic.AddInt64(&skipped, 1)
+					continue
+				}
+				assets <- asset
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(assets)
+	}()
+
+	indexed, failed := uploadInBatches(assets, apiURL, token, batchSize)
+
+	if walkErr != nil {
+		return walkErr
+	}
+
+	log.Printf("Summary: indexed=%d failed=%d skipped=%d", indexed, failed, skipped)
+	return nil
+}
+
+// buildAsset reads meta.json (if present) and hashes the file at path,
+// producing the Asset document that will be sent to the bulk endpoint.
+// It sends metadata only: ImagePath stays a path on the machine running
+// this CLI, and no blob bytes are uploaded, so bulk-imported assets get
+// no server-side storage, thumbnails, or BlurHash - those only happen via
+// the CreateAsset upload pipeline. Pointing this CLI at CreateAsset
+// instead of _bulk would restore that pipeline at the cost of losing the
+// batched indexing this command exists for.
+func buildAsset(path string) (Asset, error) {
+	metaData, err := readMetaJSON(filepath.Dir(path))
+	if err != nil {
+		return Asset{}, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return Asset{}, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return Asset{}, fmt.Errorf("error hashing file: %w", err)
+	}
+
+	asset := Asset{
+		ImagePath:     path,
+		Metadata:      metaData,
+		ContentSHA256: hex.EncodeToString(hasher.Sum(nil)),
+		CreatedAt:     time.Now().Format(time.RFC3339),
+	}
+	if id, ok := metaData["id"].(string); ok {
+		asset.ID = id
+	}
+	if createdAt, ok := metaData["created_at"].(string); ok && createdAt != "" {
+		asset.CreatedAt = createdAt
+	}
+
+	return asset, nil
+}
+
+// uploadInBatches drains assets into groups of batchSize and posts each
+// group to apiURL as NDJSON, retrying failed batches with exponential
+// backoff. Per-batch results are logged to stderr; the final tallies are
+// returned for the run summary.
+func uploadInBatches(assets <-chan Asset, apiURL, token string, batchSize int) (indexed, failed int) {
+	batch := make([]Asset, 0, batchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ok, err := postBulkWithRetry(apiURL, token, batch)
 		if err != nil {
-			return fmt.Errorf("error accessing file %s: %w", path, err)
+			log.Printf("Failed to index batch of %d assets: %s", len(batch), err)
+			failed += len(batch)
+		} else {
+			log.Printf("Indexed batch of %d assets", ok)
+			indexed += ok
+			failed += len(batch) - ok
 		}
+		batch = batch[:0]
+	}
 
-		// Skip directories
-		if d.IsDir() {
-			return nil
+	for asset := range assets {
+		batch = append(batch, asset)
+		if len(batch) >= batchSize {
+			flush()
 		}
+	}
+	flush()
 
-		// Check if the file is an image
-		if isImageFile(path) {
-			// Process the image
-			log.Printf("Processing image: %s", path)
+	return indexed, failed
+}
 
-			// Attempt to read metadata from meta.json
-			metaData, err := readMetaJSON(filepath.Dir(path))
-			if err != nil {
-				log.Printf("Error reading metadata for %s: %s", path, err)
-			}
+func postBulkWithRetry(apiURL, token string, batch []Asset) (int, error) {
+	var lastErr error
+	for attempt := 0; attempt < maxUploadRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(math.Pow(2, float64(attempt))) * time.Second)
+		}
 
-			// Add to Elasticsearch via API
-			err = addImageToAPI(path, metaData)
-			if err != nil {
-				log.Printf("Error adding image to API: %s", err)
-			}
+		n, err := postBulk(apiURL, token, batch)
+		if err == nil {
+			return n, nil
+		}
+		lastErr = err
+		log.Printf("Bulk upload attempt %d/%d failed: %s", attempt+1, maxUploadRetries, err)
+	}
+	return 0, lastErr
+}
+
+func postBulk(apiURL, token string, batch []Asset) (int, error) {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, asset := range batch {
+		if err := encoder.Encode(asset); err != nil {
+			return 0, fmt.Errorf("error encoding asset: %w", err)
 		}
+	}
 
-		return nil
-	})
+	req, err := http.NewRequest("POST", apiURL, &body)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("error sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode > 399 {
+		return 0, fmt.Errorf("server returned non-OK status: %s", resp.Status)
+	}
+
+	var result struct {
+		Indexed int `json:"indexed"`
+		Failed  int `json:"failed"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return len(batch), nil
+	}
+
+	return result.Indexed, nil
 }
 
 func isImageFile(path string) bool {
@@ -102,50 +275,3 @@ func readMetaJSON(folderPath string) (map[string]interface{}, error) {
 
 	return metaData, nil
 }
-
-func addImageToAPI(imagePath string, metadata map[string]interface{}) error {
-	// Create a document with the image path and metadata
-	var doc Asset
-
-	if id, ok := metadata["id"].(string); ok {
-		doc = Asset{
-			ID:        id,
-			ImagePath: imagePath,
-			Metadata:  metadata,
-		}
-
-	} else {
-		doc = Asset{
-			ImagePath: imagePath,
-			Metadata:  metadata,
-		}
-	}
-
-	// Serialize the document to JSON
-	docBytes, err := json.Marshal(doc)
-	if err != nil {
-		return fmt.Errorf("error serializing document: %w", err)
-	}
-
-	// Call the main Go API to handle the image indexing
-	apiURL := "http://localhost:8080/assets"
-	req, err := http.NewRequest("POST", apiURL, bytes.NewBuffer(docBytes))
-	if err != nil {
-		return fmt.Errorf("error creating request: %w", err)
-	}
-	req.Header.Set("Content-Type", "application/json")
-
-	// Send the POST request to the API
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending request to API: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode > 399 {
-		return fmt.Errorf("received non-OK response from API: %s", resp.Status)
-	}
-
-	return nil
-}
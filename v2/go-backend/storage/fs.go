@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FSBackend stores blobs as plain files under Root, joined with the key.
+// It is the default backend and preserves photosafe's original behavior of
+// keeping everything on local disk.
+type FSBackend struct {
+	Root string
+}
+
+// NewFSBackend returns a Backend rooted at root.
+func NewFSBackend(root string) *FSBackend {
+	return &FSBackend{Root: root}
+}
+
+func (b *FSBackend) path(key string) string {
+	return filepath.Join(b.Root, key)
+}
+
+func (b *FSBackend) Put(_ context.Context, key string, r io.Reader) (string, error) {
+	path := b.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return "", err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func (b *FSBackend) Get(_ context.Context, key string) (io.ReadCloser, error) {
+	return os.Open(b.path(key))
+}
+
+func (b *FSBackend) Delete(_ context.Context, key string) error {
+	err := os.Remove(b.path(key))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+// URL returns "" since the fs backend has nothing a client could fetch
+// directly; callers fall back to serving the bytes through the API.
+func (b *FSBackend) URL(key string) string {
+	return ""
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// S3Backend stores blobs as objects in a single S3(-compatible) bucket,
+// keyed directly by the key passed to Put/Get/Delete.
+type S3Backend struct {
+	client        *s3.Client
+	presignClient *s3.PresignClient
+	bucket        string
+	presignExpiry time.Duration
+}
+
+// NewS3BackendFromEnv builds an S3Backend from STORAGE_S3_BUCKET (required),
+// STORAGE_S3_REGION, and STORAGE_S3_ENDPOINT (optional, for S3-compatible
+// stores such as MinIO). Credentials are resolved the standard AWS SDK way
+// (environment, shared config, instance role, etc).
+func NewS3BackendFromEnv() (*S3Backend, error) {
+	bucket := os.Getenv("STORAGE_S3_BUCKET")
+	if bucket == "" {
+		return nil, fmt.Errorf("storage: STORAGE_S3_BUCKET is required for STORAGE_DRIVER=s3")
+	}
+
+	var opts []func(*config.LoadOptions) error
+	if region := os.Getenv("STORAGE_S3_REGION"); region != "" {
+		opts = append(opts, config.WithRegion(region))
+	}
+
+	cfg, err := config.LoadDefaultConfig(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("storage: loading AWS config: %w", err)
+	}
+
+	client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		if endpoint := os.Getenv("STORAGE_S3_ENDPOINT"); endpoint != "" {
+			o.BaseEndpoint = aws.String(endpoint)
+			o.UsePathStyle = true
+		}
+	})
+
+	return &S3Backend{
+		client:        client,
+		presignClient: s3.NewPresignClient(client),
+		bucket:        bucket,
+		presignExpiry: 15 * time.Minute,
+	}, nil
+}
+
+func (b *S3Backend) Put(ctx context.Context, key string, r io.Reader) (string, error) {
+	_, err := b.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+		Body:   r,
+	})
+	if err != nil {
+		return "", fmt.Errorf("storage: s3 put %s: %w", key, err)
+	}
+	return fmt.Sprintf("s3://%s/%s", b.bucket, key), nil
+}
+
+func (b *S3Backend) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("storage: s3 get %s: %w", key, err)
+	}
+	return out.Body, nil
+}
+
+func (b *S3Backend) Delete(ctx context.Context, key string) error {
+	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("storage: s3 delete %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL returns a presigned GET URL for key, valid for presignExpiry, so
+// callers (e.g. GetAsset) can redirect clients straight to the object
+// store instead of proxying the bytes through the API.
+func (b *S3Backend) URL(key string) string {
+	req, err := b.presignClient.PresignGetObject(context.Background(), &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(b.presignExpiry))
+	if err != nil {
+		return ""
+	}
+	return req.URL
+}
@@ -0,0 +1,47 @@
+// Package storage abstracts where asset blobs physically live so the
+// routes package can write/read/delete them without caring whether they
+// end up on local disk or in an object store.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Backend stores and retrieves asset blobs by key. Keys are
+// backend-relative paths (e.g. the content-addressable path produced by
+// casBlobKey) - callers don't interpret them beyond passing them back in.
+type Backend interface {
+	// Put stores the contents of r under key and returns a URL identifying
+	// it (the file path under Root for the fs backend, an s3:// URI for the
+	// s3 backend).
+	Put(ctx context.Context, key string, r io.Reader) (url string, err error)
+	// Get opens key for reading. The caller must close the returned reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key. Deleting a key that doesn't exist is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns a link a client can use to fetch key directly, bypassing
+	// the API server, or "" if the backend has no such concept (fs).
+	URL(key string) string
+}
+
+// New returns the Backend selected by the STORAGE_DRIVER environment
+// variable ("fs", the default, or "s3"). For "s3", STORAGE_S3_BUCKET is
+// required; STORAGE_S3_REGION and STORAGE_S3_ENDPOINT are optional
+// overrides (the latter for S3-compatible stores like MinIO).
+func New() (Backend, error) {
+	switch driver := os.Getenv("STORAGE_DRIVER"); driver {
+	case "", "fs":
+		root := os.Getenv("STORAGE_FS_ROOT")
+		if root == "" {
+			root = "./assets"
+		}
+		return NewFSBackend(root), nil
+	case "s3":
+		return NewS3BackendFromEnv()
+	default:
+		return nil, fmt.Errorf("storage: unknown STORAGE_DRIVER %q", driver)
+	}
+}
@@ -1,42 +1,131 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/jxskiss/base62"
 )
 
-// GenerateUUIDv7Base62 generates a time-based UUID (v7), removes dashes, and encodes it to Base62
+// uuidv7State guards the monotonic counter shared by every GenerateUUIDv7
+// call in the process: when two IDs are requested in the same millisecond,
+// the second reuses the previous random tail incremented by one instead of
+// drawing fresh randomness, so IDs stay strictly sortable under bursts.
+var uuidv7State struct {
+	mu       sync.Mutex
+	lastTs   int64
+	lastRand [10]byte
+}
+
+// GenerateUUIDv7 generates a monotonic, RFC 9562 §5.7-conformant UUIDv7,
+// base62-encodes it, and prepends it with "<ident>_".
 func GenerateUUIDv7(ident string) string {
-	// Get the current Unix timestamp in milliseconds
+	return FormatUUIDv7(NewUUIDv7(), ident, "base62")
+}
+
+// NewUUIDv7 returns the raw 16 bytes of a new UUIDv7: a 48-bit big-endian
+// Unix-millisecond timestamp in bytes 0-5, the version nibble (7) and RFC
+// 4122 variant bits set in bytes 6 and 8, and cryptographically random bits
+// filling the rest - except within the same millisecond, when the previous
+// call's random tail is incremented by one to preserve ordering.
+func NewUUIDv7() [16]byte {
+	uuidv7State.mu.Lock()
+	defer uuidv7State.mu.Unlock()
+
 	now := time.Now().UnixMilli()
 
-	// Create a new UUID based on the current time and random data
-	uuidBytes := make([]byte, 16)
-	copy(uuidBytes[0:8], []byte(fmt.Sprintf("%016x", now)))
+	var tail [10]byte
+	if now == uuidv7State.lastTs {
+		tail = incrementRandomTail(uuidv7State.lastRand)
+	} else if _, err := rand.Read(tail[:]); err != nil {
+		panic(fmt.Sprintf("utils: reading random bytes: %s", err))
+	}
+
+	uuidv7State.lastTs = now
+	uuidv7State.lastRand = tail
 
-	// Generate random data for the remaining part
-	randUUID := uuid.New()
-	copy(uuidBytes[8:], randUUID[:8])
+	var id [16]byte
+	ts := uint64(now) & 0xFFFFFFFFFFFF // 48 bits
+	id[0] = byte(ts >> 40)
+	id[1] = byte(ts >> 32)
+	id[2] = byte(ts >> 24)
+	id[3] = byte(ts >> 16)
+	id[4] = byte(ts >> 8)
+	id[5] = byte(ts)
+	copy(id[6:16], tail[:])
 
-	// Set version to 7 (time-based UUID)
-	uuidBytes[6] = (uuidBytes[6] & 0x0f) | (0x70) // Set the version to 7 (bits 6-7)
+	id[6] = (id[6] & 0x0f) | 0x70 // version 7
+	id[8] = (id[8] & 0x3f) | 0x80 // RFC 4122 variant
 
-	// Set variant to 2 (RFC4122)
-	uuidBytes[8] = (uuidBytes[8] & 0x3f) | 0x80 // Set the variant (bits 8-9)
+	return id
+}
+
+// incrementRandomTail treats tail as a big-endian integer and adds one,
+// carrying across bytes.
+func incrementRandomTail(tail [10]byte) [10]byte {
+	for i := len(tail) - 1; i >= 0; i-- {
+		tail[i]++
+		if tail[i] != 0 {
+			break
+		}
+	}
+	return tail
+}
+
+// FormatUUIDv7 encodes the raw bytes of a UUIDv7 (as returned by
+// NewUUIDv7) using encoding ("base62", "base32hex", or "hex") and prepends
+// "<prefix>_" if prefix is non-empty. Encoding the 16 raw bytes directly
+// (rather than their hex string representation) keeps the output compact.
+func FormatUUIDv7(id [16]byte, prefix string, encoding string) string {
+	var encoded string
+	switch encoding {
+	case "hex":
+		encoded = hex.EncodeToString(id[:])
+	case "base32hex":
+		encoded = base32.HexEncoding.WithPadding(base32.NoPadding).EncodeToString(id[:])
+	default:
+		encoded = base62.EncodeToString(id[:])
+	}
+
+	if prefix == "" {
+		return encoded
+	}
+	return prefix + "_" + encoded
+}
 
-	// Create UUID and convert to string (with dashes)
-	uuidWithoutDashes := uuid.Must(uuid.FromBytes(uuidBytes)).String()
+// ParseUUIDv7 is the inverse of FormatUUIDv7: it strips an optional
+// "<prefix>_" and decodes the remainder using encoding ("base62",
+// "base32hex", or "hex") back into the raw 16 bytes produced by
+// NewUUIDv7. It returns an error if the decoded value isn't 16 bytes long.
+func ParseUUIDv7(s string, encoding string) ([16]byte, error) {
+	var id [16]byte
 
-	// Remove dashes from the UUID string
-	uuidClean := strings.ReplaceAll(uuidWithoutDashes, "-", "")
+	if _, rest, ok := strings.Cut(s, "_"); ok {
+		s = rest
+	}
 
-	// Encode the UUID without dashes in Base62
-	base62Encoded := base62.EncodeToString([]byte(uuidClean))
+	var decoded []byte
+	var err error
+	switch encoding {
+	case "hex":
+		decoded, err = hex.DecodeString(s)
+	case "base32hex":
+		decoded, err = base32.HexEncoding.WithPadding(base32.NoPadding).DecodeString(s)
+	default:
+		decoded, err = base62.DecodeString(s)
+	}
+	if err != nil {
+		return id, fmt.Errorf("utils: decoding UUIDv7: %w", err)
+	}
+	if len(decoded) != len(id) {
+		return id, fmt.Errorf("utils: decoded UUIDv7 has %d bytes, want %d", len(decoded), len(id))
+	}
 
-	// Prepend the custom prefix
-	return ident + "_" + base62Encoded
+	copy(id[:], decoded)
+	return id, nil
 }
@@ -0,0 +1,60 @@
+package utils
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNewUUIDv7_VersionAndVariantBits(t *testing.T) {
+	id := NewUUIDv7()
+
+	if got := id[6] >> 4; got != 0x7 {
+		t.Errorf("version nibble = %x, want 7", got)
+	}
+	if got := id[8] >> 6; got != 0x2 {
+		t.Errorf("variant bits = %02b, want 10", got)
+	}
+}
+
+func TestNewUUIDv7_MonotonicWithinBurst(t *testing.T) {
+	const n = 100000
+
+	ids := make([][16]byte, n)
+	for i := range ids {
+		ids[i] = NewUUIDv7()
+	}
+
+	for i := 1; i < n; i++ {
+		if bytes.Compare(ids[i-1][:], ids[i][:]) >= 0 {
+			t.Fatalf("id %d (%x) is not strictly less than id %d (%x)", i-1, ids[i-1], i, ids[i])
+		}
+	}
+}
+
+func TestFormatParseUUIDv7_RoundTrip(t *testing.T) {
+	for _, encoding := range []string{"base62", "base32hex", "hex"} {
+		id := NewUUIDv7()
+		formatted := FormatUUIDv7(id, "ast", encoding)
+
+		got, err := ParseUUIDv7(formatted, encoding)
+		if err != nil {
+			t.Fatalf("ParseUUIDv7(%q, %q): %v", formatted, encoding, err)
+		}
+		if got != id {
+			t.Errorf("ParseUUIDv7(%q, %q) = %x, want %x", formatted, encoding, got, id)
+		}
+	}
+}
+
+func TestParseUUIDv7_NoPrefix(t *testing.T) {
+	id := NewUUIDv7()
+	formatted := FormatUUIDv7(id, "", "base62")
+
+	got, err := ParseUUIDv7(formatted, "base62")
+	if err != nil {
+		t.Fatalf("ParseUUIDv7(%q): %v", formatted, err)
+	}
+	if got != id {
+		t.Errorf("ParseUUIDv7(%q) = %x, want %x", formatted, got, id)
+	}
+}